@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// bulkLoadOptions carries the -load flags needed to stream a delimited file
+// into a table through each engine's native bulk-load protocol.
+type bulkLoadOptions struct {
+	table     string
+	columns   []string
+	file      string
+	delimiter rune
+	nullStr   string
+	batchSize int
+	skipRows  int
+}
+
+// BulkLoader streams rows from a delimited file into table using the
+// fastest protocol the engine exposes, rather than single-row INSERTs.
+type BulkLoader interface {
+	Load(ctx context.Context, db *sql.DB, opts bulkLoadOptions) (rowsLoaded int64, err error)
+}
+
+func bulkLoaderFor(engineName string) (BulkLoader, error) {
+	switch strings.ToLower(engineName) {
+	case "postgres":
+		return postgresBulkLoader{}, nil
+	case "sqlserver":
+		return sqlserverBulkLoader{}, nil
+	case "oracle":
+		return oracleBulkLoader{}, nil
+	default:
+		return nil, fmt.Errorf("-load is not supported for engine: %s", engineName)
+	}
+}
+
+// runLoad picks the engine's BulkLoader and hands it opts; the loader itself
+// opens opts.file, resolves the column list and skips opts.skipRows rows.
+// It reports throughput once the load completes, with MB/sec based on the
+// whole file's size.
+func runLoad(ctx context.Context, db *sql.DB, cfg config, opts bulkLoadOptions) error {
+	loader, err := bulkLoaderFor(cfg.engine)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(opts.file)
+	if err != nil {
+		return fmt.Errorf("failed to stat -file: %w", err)
+	}
+
+	start := time.Now()
+	rowsLoaded, err := loader.Load(ctx, db, opts)
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(start).Seconds()
+	if elapsed <= 0 {
+		elapsed = 0.001
+	}
+	fmt.Printf("loaded %d rows in %.2fs (%.0f rows/sec, %.2f MB/sec)\n",
+		rowsLoaded, elapsed, float64(rowsLoaded)/elapsed, float64(info.Size())/(1024*1024)/elapsed)
+	return nil
+}
+
+// resolveColumns opens the source file and returns its header-derived
+// column list plus a reader positioned at the first data row, honoring
+// opts.skipRows. If opts.columns was set explicitly, the file is assumed
+// to have no header and every line is data.
+func resolveColumns(opts bulkLoadOptions) ([]string, *csv.Reader, *os.File, error) {
+	f, err := os.Open(opts.file)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to open -file: %w", err)
+	}
+
+	reader := csv.NewReader(f)
+	reader.Comma = opts.delimiter
+	reader.FieldsPerRecord = -1
+
+	columns := opts.columns
+	if len(columns) == 0 {
+		header, err := reader.Read()
+		if err != nil {
+			f.Close()
+			return nil, nil, nil, fmt.Errorf("failed to read header row: %w", err)
+		}
+		columns = header
+	}
+
+	for i := 0; i < opts.skipRows; i++ {
+		if _, err := reader.Read(); err == io.EOF {
+			break
+		} else if err != nil {
+			f.Close()
+			return nil, nil, nil, fmt.Errorf("failed to skip rows: %w", err)
+		}
+	}
+
+	return columns, reader, f, nil
+}
+
+// postgresBulkLoader streams the file through the wire-level COPY protocol
+// via pgx's PgConn, which is far faster than row-by-row INSERTs.
+type postgresBulkLoader struct{}
+
+func (postgresBulkLoader) Load(ctx context.Context, db *sql.DB, opts bulkLoadOptions) (int64, error) {
+	f, err := os.Open(opts.file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open -file: %w", err)
+	}
+	defer f.Close()
+
+	// Read line-by-line just to determine the column list and skip past
+	// the header/skip-rows; the remainder of the bufio.Reader is then
+	// handed to CopyFrom as-is, since the server parses CSV itself.
+	buffered := bufio.NewReader(f)
+	columns := opts.columns
+	linesToSkip := opts.skipRows
+	if len(columns) == 0 {
+		header, err := readDelimitedLine(buffered, opts.delimiter)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read header row: %w", err)
+		}
+		columns = header
+	}
+	for i := 0; i < linesToSkip; i++ {
+		if _, err := readDelimitedLine(buffered, opts.delimiter); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return 0, fmt.Errorf("failed to skip rows: %w", err)
+		}
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire pgx connection: %w", err)
+	}
+	defer conn.Close()
+
+	quotedColumns := make([]string, len(columns))
+	for i, col := range columns {
+		quotedColumns[i] = postgresEngine{}.QuoteIdentifier(col)
+	}
+	copySQL := fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (FORMAT csv, DELIMITER '%c', NULL '%s')",
+		quoteQualifiedIdentifier(postgresEngine{}, opts.table), strings.Join(quotedColumns, ", "), opts.delimiter, opts.nullStr)
+
+	var rowsLoaded int64
+	err = conn.Raw(func(driverConn any) error {
+		pconn := driverConn.(*stdlib.Conn).Conn()
+		tag, err := pconn.PgConn().CopyFrom(ctx, buffered, copySQL)
+		if err != nil {
+			return err
+		}
+		rowsLoaded = tag.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("COPY FROM failed: %w", err)
+	}
+	return rowsLoaded, nil
+}
+
+// readDelimitedLine reads and parses one CSV-style line from r, used only
+// to locate where the header/skipped rows end in the byte stream.
+func readDelimitedLine(r *bufio.Reader, delimiter rune) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && line == "" {
+		return nil, err
+	}
+	lineReader := csv.NewReader(strings.NewReader(line))
+	lineReader.Comma = delimiter
+	record, parseErr := lineReader.Read()
+	if parseErr != nil && parseErr != io.EOF {
+		return nil, parseErr
+	}
+	return record, nil
+}
+
+// sqlserverBulkLoader drives SQL Server's TDS bulk-insert protocol via
+// mssql.CopyIn, batching rows in a single prepared statement.
+type sqlserverBulkLoader struct{}
+
+func (sqlserverBulkLoader) Load(ctx context.Context, db *sql.DB, opts bulkLoadOptions) (int64, error) {
+	columns, reader, f, err := resolveColumns(opts)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	stmt, err := db.PrepareContext(ctx, mssql.CopyIn(opts.table, mssql.BulkOptions{}, columns...))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare BULK INSERT: %w", err)
+	}
+
+	var rowsLoaded int64
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			_ = stmt.Close()
+			return rowsLoaded, fmt.Errorf("failed to read row %d: %w", rowsLoaded+1, err)
+		}
+		args := make([]any, len(record))
+		for i, field := range record {
+			if field == opts.nullStr {
+				args[i] = nil
+			} else {
+				args[i] = field
+			}
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			_ = stmt.Close()
+			return rowsLoaded, fmt.Errorf("failed to queue row %d: %w", rowsLoaded+1, err)
+		}
+		rowsLoaded++
+	}
+
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		_ = stmt.Close()
+		return rowsLoaded, fmt.Errorf("failed to flush BULK INSERT: %w", err)
+	}
+	return rowsLoaded, stmt.Close()
+}
+
+// oracleBulkLoader has no wire-level bulk protocol available through
+// database/sql, so it falls back to batched parameterized INSERTs of
+// opts.batchSize rows each, still far fewer round trips than one per row.
+type oracleBulkLoader struct{}
+
+func (oracleBulkLoader) Load(ctx context.Context, db *sql.DB, opts bulkLoadOptions) (int64, error) {
+	columns, reader, f, err := resolveColumns(opts)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	batchSize := opts.batchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	var rowsLoaded int64
+	batch := make([][]string, 0, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := insertBatch(ctx, db, opts, columns, batch); err != nil {
+			return err
+		}
+		rowsLoaded += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rowsLoaded, fmt.Errorf("failed to read row %d: %w", rowsLoaded+1, err)
+		}
+		batch = append(batch, record)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return rowsLoaded, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return rowsLoaded, err
+	}
+	return rowsLoaded, nil
+}
+
+// insertBatch builds one parameterized INSERT ALL statement with an
+// INTO ... VALUES clause per row in the batch, using Oracle-style
+// positional binds (:1, :2, ...).
+func insertBatch(ctx context.Context, db *sql.DB, opts bulkLoadOptions, columns []string, batch [][]string) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "INSERT ALL\n")
+	quotedColumns := make([]string, len(columns))
+	for i, col := range columns {
+		quotedColumns[i] = oracleEngine{}.QuoteIdentifier(col)
+	}
+	args := make([]any, 0, len(batch)*len(columns))
+	bind := 1
+	for _, record := range batch {
+		placeholders := make([]string, len(columns))
+		for i := range columns {
+			placeholders[i] = fmt.Sprintf(":%d", bind)
+			bind++
+			var field string
+			if i < len(record) {
+				field = record[i]
+			}
+			if field == opts.nullStr {
+				args = append(args, nil)
+			} else {
+				args = append(args, field)
+			}
+		}
+		fmt.Fprintf(&sb, "INTO %s (%s) VALUES (%s)\n", quoteQualifiedIdentifier(oracleEngine{}, opts.table), strings.Join(quotedColumns, ", "), strings.Join(placeholders, ", "))
+	}
+	sb.WriteString("SELECT 1 FROM dual")
+
+	_, err := db.ExecContext(ctx, sb.String(), args...)
+	if err != nil {
+		return fmt.Errorf("batch insert failed: %w", err)
+	}
+	return nil
+}
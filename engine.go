@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// engine captures the per-database-engine knowledge (driver name, DSN
+// construction, default port, identifier quoting) so new engines plug in by
+// adding one implementation and a registry entry, rather than growing a
+// switch statement in every function that touches connection details.
+type engine interface {
+	Driver() string
+	DSN(cfg config) string
+	DefaultPort() int
+	QuoteIdentifier(name string) string
+}
+
+var engines = map[string]engine{
+	"oracle":    oracleEngine{},
+	"sqlserver": sqlserverEngine{},
+	"postgres":  postgresEngine{},
+	"sqlite":    sqliteEngine{},
+	"mysql":     mysqlEngine{},
+}
+
+func lookupEngine(name string) (engine, bool) {
+	e, ok := engines[strings.ToLower(name)]
+	return e, ok
+}
+
+// quoteQualifiedIdentifier quotes a possibly schema-qualified identifier
+// (e.g. "public.accounts") one segment at a time, so the separating dot
+// isn't swallowed into the quoted name.
+func quoteQualifiedIdentifier(e engine, name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = e.QuoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+type oracleEngine struct{}
+
+func (oracleEngine) Driver() string { return "oracle" }
+
+func (oracleEngine) DSN(cfg config) string {
+	return fmt.Sprintf("oracle://%s:%s@%s:%d/%s", url.PathEscape(cfg.username), url.PathEscape(cfg.password), cfg.host, cfg.port, cfg.dbname)
+}
+
+func (oracleEngine) DefaultPort() int { return 1521 }
+
+func (oracleEngine) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+type sqlserverEngine struct{}
+
+func (sqlserverEngine) Driver() string { return "sqlserver" }
+
+func (sqlserverEngine) DSN(cfg config) string {
+	query := url.Values{}
+	query.Set("database", cfg.dbname)
+	return fmt.Sprintf("sqlserver://%s:%s@%s:%d?%s", url.PathEscape(cfg.username), url.PathEscape(cfg.password), cfg.host, cfg.port, query.Encode())
+}
+
+func (sqlserverEngine) DefaultPort() int { return 1433 }
+
+func (sqlserverEngine) QuoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+type postgresEngine struct{}
+
+func (postgresEngine) Driver() string { return "pgx" }
+
+func (postgresEngine) DSN(cfg config) string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable", cfg.host, cfg.port, cfg.username, cfg.password, cfg.dbname)
+}
+
+func (postgresEngine) DefaultPort() int { return 5432 }
+
+func (postgresEngine) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqliteEngine treats -dbname as the database file path; -host, -username
+// and -password are ignored since SQLite has no server to connect to.
+type sqliteEngine struct{}
+
+func (sqliteEngine) Driver() string { return "sqlite" }
+
+func (sqliteEngine) DSN(cfg config) string { return cfg.dbname }
+
+func (sqliteEngine) DefaultPort() int { return 0 }
+
+func (sqliteEngine) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+type mysqlEngine struct{}
+
+func (mysqlEngine) Driver() string { return "mysql" }
+
+func (mysqlEngine) DSN(cfg config) string {
+	// go-sql-driver/mysql parses the user:pass@ prefix as raw substrings and
+	// never URL-decodes them, so credentials must be passed through as-is;
+	// escaping here would corrupt passwords containing '%' or '@'.
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&multiStatements=false", cfg.username, cfg.password, cfg.host, cfg.port, cfg.dbname)
+}
+
+func (mysqlEngine) DefaultPort() int { return 3306 }
+
+func (mysqlEngine) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// isolationLevel maps the -isolation flag to a database/sql isolation level
+// plus an optional extra statement to run right after BeginTx, needed for
+// engines that don't expose the desired level through sql.TxOptions alone
+// (Postgres has no driver-level "snapshot" constant, so we approximate it
+// with REPEATABLE READ, matching the dendrite txReadOnlySnapshot pattern).
+func isolationLevel(isolation, engine string) (sql.IsolationLevel, string, error) {
+	switch isolation {
+	case "", "default":
+		return sql.LevelDefault, "", nil
+	case "readcommitted":
+		return sql.LevelReadCommitted, "", nil
+	case "repeatable":
+		return sql.LevelRepeatableRead, "", nil
+	case "serializable":
+		return sql.LevelSerializable, "", nil
+	case "snapshot":
+		switch strings.ToLower(engine) {
+		case "sqlserver":
+			return sql.LevelSnapshot, "", nil
+		case "postgres":
+			return sql.LevelDefault, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ", nil
+		default:
+			return sql.LevelDefault, "", nil
+		}
+	default:
+		return sql.LevelDefault, "", fmt.Errorf("unsupported isolation level: %s", isolation)
+	}
+}
+
+// isReadOnlyStatement reports whether statement is a query that is safe to
+// run in a read-only transaction: only SELECT and WITH statements qualify.
+// PRAGMA and EXPLAIN, which executeStatement also runs as queries, do not.
+func isReadOnlyStatement(statement string) bool {
+	normalized := strings.ToLower(strings.TrimSpace(statement))
+	return strings.HasPrefix(normalized, "select") || strings.HasPrefix(normalized, "with")
+}
+
+// execInTx runs all statements inside a single transaction, honoring
+// -readonly and -isolation, and rolls back on the first error.
+func execInTx(ctx context.Context, db *sql.DB, cfg config, statements []string, out io.Writer) error {
+	level, extra, err := isolationLevel(cfg.isolation, cfg.engine)
+	if err != nil {
+		return err
+	}
+
+	if cfg.readonly {
+		for _, statement := range statements {
+			if !isReadOnlyStatement(statement) {
+				return fmt.Errorf("-readonly mode only allows SELECT/WITH statements, got: %.40s", strings.TrimSpace(statement))
+			}
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: cfg.readonly, Isolation: level})
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	if extra != "" {
+		if _, err := tx.ExecContext(ctx, extra); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to set isolation level: %w", err)
+		}
+	}
+
+	for idx, statement := range statements {
+		if err := executeStatement(ctx, tx, idx+1, statement, cfg, out); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("statement %d failed: %w", idx+1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// execNoTx runs each statement independently in autocommit mode, which is
+// required by DDL that engines refuse inside a transaction (e.g. Postgres
+// CREATE INDEX CONCURRENTLY, Oracle ALTER SYSTEM). -on-error controls
+// whether a failing statement aborts the run or is merely reported.
+func execNoTx(ctx context.Context, db *sql.DB, cfg config, statements []string, out io.Writer) error {
+	for idx, statement := range statements {
+		if err := executeStatement(ctx, db, idx+1, statement, cfg, out); err != nil {
+			fmt.Printf("statement %d failed: %v\n", idx+1, err)
+			if cfg.onError == "stop" {
+				return fmt.Errorf("statement %d failed: %w", idx+1, err)
+			}
+		}
+	}
+	return nil
+}
@@ -7,14 +7,16 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"net/url"
+	"io"
 	"os"
 	"strings"
 	"time"
 
 	_ "github.com/denisenkom/go-mssqldb"
+	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/sijms/go-ora"
+	_ "modernc.org/sqlite"
 )
 
 type config struct {
@@ -25,17 +27,61 @@ type config struct {
 	password string
 	dbname   string
 	sqlPath  string
+
+	mode          string
+	migrationsDir string
+	target        int64
+	steps         int
+	force         int64
+
+	readonly  bool
+	isolation string
+	noTx      bool
+	onError   string
+
+	format     string
+	outputPath string
+	nullString string
+
+	load          bool
+	table         string
+	loadFile      string
+	loadColumns   string
+	loadDelimiter string
+	loadNull      string
+	batchSize     int
+	skipRows      int
 }
 
 func main() {
 	cfg := config{}
-	flag.StringVar(&cfg.engine, "engine", "", "database engine: oracle, sqlserver, postgres")
+	flag.StringVar(&cfg.engine, "engine", "", "database engine: oracle, sqlserver, postgres, sqlite, mysql")
 	flag.StringVar(&cfg.host, "host", "", "database host")
 	flag.IntVar(&cfg.port, "port", 0, "database port")
 	flag.StringVar(&cfg.username, "username", "db_admin", "database username")
 	flag.StringVar(&cfg.password, "password", "", "database password")
 	flag.StringVar(&cfg.dbname, "dbname", "", "database name or service")
 	flag.StringVar(&cfg.sqlPath, "sql", "", "path to SQL file")
+	flag.StringVar(&cfg.mode, "mode", "exec", "operation mode: exec, migrate")
+	flag.StringVar(&cfg.migrationsDir, "migrations-dir", "migrations", "directory of numbered *.up.sql/*.down.sql files (migrate mode)")
+	flag.Int64Var(&cfg.target, "target", 0, "target migration version to migrate up to (migrate mode, 0 = latest)")
+	flag.IntVar(&cfg.steps, "steps", 0, "number of pending migrations to apply (migrate mode, 0 = all)")
+	flag.Int64Var(&cfg.force, "force", -1, "clear the dirty flag on this version and exit (migrate mode)")
+	flag.BoolVar(&cfg.readonly, "readonly", false, "open a read-only transaction and refuse non-SELECT/WITH statements")
+	flag.StringVar(&cfg.isolation, "isolation", "default", "transaction isolation: default, readcommitted, repeatable, serializable, snapshot")
+	flag.BoolVar(&cfg.noTx, "no-tx", false, "execute each statement independently without wrapping in a transaction")
+	flag.StringVar(&cfg.onError, "on-error", "stop", "behavior on statement failure in -no-tx mode: stop, continue")
+	flag.StringVar(&cfg.format, "format", "table", "output format: table, json, ndjson, csv, tsv, markdown")
+	flag.StringVar(&cfg.outputPath, "output", "", "file to write query output to (default stdout)")
+	flag.StringVar(&cfg.nullString, "null-string", "", "string used to represent NULL in csv/tsv/markdown output")
+	flag.BoolVar(&cfg.load, "load", false, "bulk-load -file into -table instead of executing -sql")
+	flag.StringVar(&cfg.table, "table", "", "target table for -load, e.g. schema.tbl")
+	flag.StringVar(&cfg.loadFile, "file", "", "delimited data file to bulk-load (-load mode)")
+	flag.StringVar(&cfg.loadColumns, "columns", "", "comma-separated column list for -load (default: file's header row)")
+	flag.StringVar(&cfg.loadDelimiter, "delimiter", ",", "field delimiter for -load")
+	flag.StringVar(&cfg.loadNull, "null", "", "string representing NULL in the -load file")
+	flag.IntVar(&cfg.batchSize, "batch-size", 500, "rows per batch for -load on engines without native bulk load (Oracle)")
+	flag.IntVar(&cfg.skipRows, "skip-rows", 0, "number of data rows to skip before loading, for resuming a -load")
 	flag.Parse()
 
 	if err := cfg.validate(); err != nil {
@@ -43,6 +89,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	if cfg.mode == "migrate" || cfg.load {
+		runMain(cfg, nil)
+		return
+	}
+
+	if cfg.sqlPath == "" {
+		if err := runRepl(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	sqlBytes, err := os.ReadFile(cfg.sqlPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to read SQL file: %v\n", err)
@@ -55,6 +114,10 @@ func main() {
 		os.Exit(1)
 	}
 
+	runMain(cfg, statements)
+}
+
+func runMain(cfg config, statements []string) {
 	db, err := sql.Open(driverName(cfg.engine), buildDSN(cfg))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to open database: %v\n", err)
@@ -70,22 +133,54 @@ func main() {
 		os.Exit(1)
 	}
 
-	tx, err := db.BeginTx(ctx, nil)
+	if cfg.mode == "migrate" {
+		if err := runMigrate(ctx, db, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.load {
+		opts := bulkLoadOptions{
+			table:     cfg.table,
+			file:      cfg.loadFile,
+			nullStr:   cfg.loadNull,
+			batchSize: cfg.batchSize,
+			skipRows:  cfg.skipRows,
+		}
+		if cfg.loadColumns != "" {
+			opts.columns = strings.Split(cfg.loadColumns, ",")
+		}
+		if d := []rune(cfg.loadDelimiter); len(d) > 0 {
+			opts.delimiter = d[0]
+		} else {
+			opts.delimiter = ','
+		}
+		if err := runLoad(ctx, db, cfg, opts); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	out, closeOut, err := openOutput(cfg.outputPath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to start transaction: %v\n", err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	defer closeOut()
 
-	for idx, statement := range statements {
-		if err := executeStatement(ctx, tx, idx+1, statement); err != nil {
-			_ = tx.Rollback()
-			fmt.Fprintf(os.Stderr, "statement %d failed: %v\n", idx+1, err)
+	if cfg.noTx {
+		if err := execNoTx(ctx, db, cfg, statements, out); err != nil {
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
+		return
 	}
 
-	if err := tx.Commit(); err != nil {
-		fmt.Fprintf(os.Stderr, "failed to commit transaction: %v\n", err)
+	if err := execInTx(ctx, db, cfg, statements, out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
@@ -94,84 +189,108 @@ func (c *config) validate() error {
 	if c.engine == "" {
 		return errors.New("engine is required")
 	}
-	if c.host == "" {
+	if c.host == "" && strings.ToLower(c.engine) != "sqlite" {
 		return errors.New("host is required")
 	}
 	if c.dbname == "" {
 		return errors.New("dbname is required")
 	}
-	if c.sqlPath == "" {
-		return errors.New("sql path is required")
-	}
-	switch strings.ToLower(c.engine) {
-	case "oracle", "sqlserver", "postgres":
+	switch {
+	case c.load:
+		if c.table == "" {
+			return errors.New("-table is required for -load")
+		}
+		if c.loadFile == "" {
+			return errors.New("-file is required for -load")
+		}
+	case c.mode == "exec" || c.mode == "":
+		// c.sqlPath == "" means drop into the interactive REPL.
+	case c.mode == "migrate":
+		if c.migrationsDir == "" {
+			return errors.New("migrations dir is required")
+		}
 	default:
+		return fmt.Errorf("unsupported mode: %s", c.mode)
+	}
+	if _, ok := lookupEngine(c.engine); !ok {
 		return fmt.Errorf("unsupported engine: %s", c.engine)
 	}
+	switch c.isolation {
+	case "default", "readcommitted", "repeatable", "serializable", "snapshot":
+	default:
+		return fmt.Errorf("unsupported isolation level: %s", c.isolation)
+	}
+	if c.noTx {
+		switch c.onError {
+		case "stop", "continue":
+		default:
+			return fmt.Errorf("unsupported on-error behavior: %s", c.onError)
+		}
+	}
+	if c.readonly && c.noTx {
+		return errors.New("-readonly and -no-tx cannot be combined")
+	}
+	switch c.format {
+	case "table", "json", "ndjson", "csv", "tsv", "markdown":
+	default:
+		return fmt.Errorf("unsupported output format: %s", c.format)
+	}
 	if c.port == 0 {
 		c.port = defaultPort(c.engine)
 	}
 	return nil
 }
 
-func defaultPort(engine string) int {
-	switch strings.ToLower(engine) {
-	case "oracle":
-		return 1521
-	case "sqlserver":
-		return 1433
-	case "postgres":
-		return 5432
-	default:
+func defaultPort(engineName string) int {
+	e, ok := lookupEngine(engineName)
+	if !ok {
 		return 0
 	}
+	return e.DefaultPort()
 }
 
-func driverName(engine string) string {
-	switch strings.ToLower(engine) {
-	case "oracle":
-		return "oracle"
-	case "sqlserver":
-		return "sqlserver"
-	case "postgres":
-		return "pgx"
-	default:
+func driverName(engineName string) string {
+	e, ok := lookupEngine(engineName)
+	if !ok {
 		return ""
 	}
+	return e.Driver()
 }
 
 func buildDSN(cfg config) string {
-	switch strings.ToLower(cfg.engine) {
-	case "oracle":
-		return fmt.Sprintf("oracle://%s:%s@%s:%d/%s", url.PathEscape(cfg.username), url.PathEscape(cfg.password), cfg.host, cfg.port, cfg.dbname)
-	case "sqlserver":
-		query := url.Values{}
-		query.Set("database", cfg.dbname)
-		return fmt.Sprintf("sqlserver://%s:%s@%s:%d?%s", url.PathEscape(cfg.username), url.PathEscape(cfg.password), cfg.host, cfg.port, query.Encode())
-	case "postgres":
-		return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable", cfg.host, cfg.port, cfg.username, cfg.password, cfg.dbname)
-	default:
+	e, ok := lookupEngine(cfg.engine)
+	if !ok {
 		return ""
 	}
+	return e.DSN(cfg)
+}
+
+// querier is satisfied by both *sql.Tx and *sql.DB, letting executeStatement
+// run inside a transaction or directly against the connection pool in
+// autocommit (-no-tx) mode.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
-func executeStatement(ctx context.Context, tx *sql.Tx, index int, statement string) error {
+func executeStatement(ctx context.Context, q querier, index int, statement string, cfg config, out io.Writer) error {
 	trimmed := strings.TrimSpace(statement)
 	if trimmed == "" {
 		return nil
 	}
 	normalized := strings.ToLower(trimmed)
-	if strings.HasPrefix(normalized, "select") || strings.HasPrefix(normalized, "with") {
-		rows, err := tx.QueryContext(ctx, statement)
+	if strings.HasPrefix(normalized, "select") || strings.HasPrefix(normalized, "with") ||
+		strings.HasPrefix(normalized, "pragma") || strings.HasPrefix(normalized, "explain") {
+		rows, err := q.QueryContext(ctx, statement)
 		if err != nil {
 			return err
 		}
 		defer rows.Close()
-		fmt.Printf("\n-- Statement %d (query)\n", index)
-		return printRows(rows)
+		fmt.Fprintf(os.Stderr, "\n-- Statement %d (query)\n", index)
+		return writeRows(rows, cfg, out)
 	}
 
-	result, err := tx.ExecContext(ctx, statement)
+	result, err := q.ExecContext(ctx, statement)
 	if err != nil {
 		return err
 	}
@@ -190,89 +309,61 @@ func executeStatement(ctx context.Context, tx *sql.Tx, index int, statement stri
 	return nil
 }
 
-func printRows(rows *sql.Rows) error {
+// writeRows streams rows through the RowWriter selected by cfg.format,
+// using rows.ColumnTypes() so writers can special-case numeric and time
+// columns without re-deriving that from the scanned value alone.
+func writeRows(rows *sql.Rows, cfg config, out io.Writer) error {
 	columns, err := rows.Columns()
 	if err != nil {
 		return err
 	}
-	var data [][]string
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	writer, err := newRowWriter(cfg.format, out, cfg.nullString)
+	if err != nil {
+		return err
+	}
+	if err := writer.WriteHeader(columns, types); err != nil {
+		return err
+	}
+
+	values := make([]any, len(columns))
+	valuePtrs := make([]any, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
 	for rows.Next() {
-		values := make([]any, len(columns))
-		valuePtrs := make([]any, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
 		if err := rows.Scan(valuePtrs...); err != nil {
 			return err
 		}
-		row := make([]string, len(columns))
-		for i, value := range values {
-			if value == nil {
-				row[i] = "NULL"
-				continue
-			}
-			switch v := value.(type) {
-			case []byte:
-				row[i] = string(v)
-			default:
-				row[i] = fmt.Sprint(v)
-			}
+		if err := writer.WriteRow(values); err != nil {
+			return err
 		}
-		data = append(data, row)
 	}
 	if err := rows.Err(); err != nil {
 		return err
 	}
-	printTable(columns, data)
-	return nil
-}
-
-func printTable(columns []string, data [][]string) {
-	widths := make([]int, len(columns))
-	for i, col := range columns {
-		widths[i] = len(col)
-	}
-	for _, row := range data {
-		for i, cell := range row {
-			if len(cell) > widths[i] {
-				widths[i] = len(cell)
-			}
-		}
-	}
-	printSeparator(widths)
-	printRow(columns, widths)
-	printSeparator(widths)
-	for _, row := range data {
-		printRow(row, widths)
-	}
-	printSeparator(widths)
+	return writer.Close()
 }
 
-func printSeparator(widths []int) {
-	var sb strings.Builder
-	sb.WriteString("+")
-	for _, width := range widths {
-		sb.WriteString(strings.Repeat("-", width+2))
-		sb.WriteString("+")
+// splitSQLStatements splits a whole SQL file into statements, treating any
+// trailing text with no terminating ';' as a final statement of its own.
+func splitSQLStatements(input string) []string {
+	statements, remainder := scanStatements(input)
+	if remainder := strings.TrimSpace(remainder); remainder != "" {
+		statements = append(statements, remainder)
 	}
-	fmt.Println(sb.String())
-}
-
-func printRow(row []string, widths []int) {
-	var sb strings.Builder
-	sb.WriteString("|")
-	for i, cell := range row {
-		padding := widths[i] - len(cell)
-		sb.WriteString(" ")
-		sb.WriteString(cell)
-		sb.WriteString(strings.Repeat(" ", padding+1))
-		sb.WriteString("|")
-	}
-	fmt.Println(sb.String())
+	return statements
 }
 
-func splitSQLStatements(input string) []string {
-	var statements []string
+// scanStatements is the shared state machine behind splitSQLStatements and
+// the REPL: it returns every ';'-terminated statement found in input, plus
+// whatever trails the last terminator (which may be an in-progress
+// statement, e.g. mid multi-line $$...$$ block, blank, or both).
+func scanStatements(input string) (statements []string, remainder string) {
 	reader := bufio.NewReader(strings.NewReader(input))
 	var sb strings.Builder
 	var inSingle, inDouble bool
@@ -389,8 +480,5 @@ func splitSQLStatements(input string) []string {
 		sb.WriteRune(ch)
 	}
 
-	if statement := strings.TrimSpace(sb.String()); statement != "" {
-		statements = append(statements, statement)
-	}
-	return statements
+	return statements, sb.String()
 }
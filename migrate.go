@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migration describes a single numbered migration step discovered on disk.
+// Either upPath or downPath may be empty if only one direction is present.
+type migration struct {
+	version     int64
+	description string
+	upPath      string
+	downPath    string
+}
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(.*)\.(up|down)\.sql$`)
+
+// loadMigrations scans dir for files named like 001_create_users.up.sql /
+// 001_create_users.down.sql and returns them sorted by version.
+func loadMigrations(dir string) ([]migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations dir: %w", err)
+	}
+
+	byVersion := map[int64]*migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, description: match[2]}
+			byVersion[version] = m
+		}
+		path := filepath.Join(dir, entry.Name())
+		if match[3] == "up" {
+			m.upPath = path
+		} else {
+			m.downPath = path
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// createSchemaMigrationsTableDDL returns the per-engine DDL to create the
+// tracking table, since Oracle/SQL Server/Postgres disagree on boolean and
+// timestamp types.
+func createSchemaMigrationsTableDDL(engine string) string {
+	switch strings.ToLower(engine) {
+	case "oracle":
+		return `BEGIN
+  EXECUTE IMMEDIATE 'CREATE TABLE schema_migrations (
+    version NUMBER(19) PRIMARY KEY,
+    dirty NUMBER(1) DEFAULT 0 NOT NULL,
+    applied_at TIMESTAMP DEFAULT SYSTIMESTAMP
+  )';
+EXCEPTION
+  WHEN OTHERS THEN
+    IF SQLCODE != -955 THEN
+      RAISE;
+    END IF;
+END;`
+	case "sqlserver":
+		return `IF OBJECT_ID('schema_migrations', 'U') IS NULL
+CREATE TABLE schema_migrations (
+  version BIGINT PRIMARY KEY,
+  dirty BIT NOT NULL DEFAULT 0,
+  applied_at DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+)`
+	case "postgres":
+		return `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version BIGINT PRIMARY KEY,
+  dirty BOOLEAN NOT NULL DEFAULT FALSE,
+  applied_at TIMESTAMP NOT NULL DEFAULT now()
+)`
+	default:
+		return ""
+	}
+}
+
+// acquireMigrationLock takes an engine-specific advisory/named lock so that
+// concurrent runs of the tool against the same database serialize.
+func acquireMigrationLock(ctx context.Context, db *sql.DB, engine string) (func(), error) {
+	switch strings.ToLower(engine) {
+	case "postgres":
+		const lockKey = 72915 // arbitrary fixed advisory lock id for this tool
+		if _, err := db.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		return func() {
+			_, _ = db.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey)
+		}, nil
+	case "sqlserver":
+		var result int
+		row := db.QueryRowContext(ctx, "DECLARE @res INT; EXEC @res = sp_getapplock @Resource = 'thin-cross-db-client-migrate', @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = 60000; SELECT @res")
+		if err := row.Scan(&result); err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if result < 0 {
+			return nil, fmt.Errorf("sp_getapplock returned %d", result)
+		}
+		return func() {
+			_, _ = db.ExecContext(ctx, "EXEC sp_releaseapplock @Resource = 'thin-cross-db-client-migrate', @LockOwner = 'Session'")
+		}, nil
+	case "oracle":
+		if _, err := db.ExecContext(ctx, "DECLARE lh VARCHAR2(128); ret INTEGER; BEGIN DBMS_LOCK.ALLOCATE_UNIQUE('thin-cross-db-client-migrate', lh); ret := DBMS_LOCK.REQUEST(lh, DBMS_LOCK.X_MODE); END;"); err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		return func() {}, nil
+	default:
+		return func() {}, nil
+	}
+}
+
+// appliedVersions returns the set of migration versions already recorded as
+// applied (dirty or clean) in schema_migrations.
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int64]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int64]bool{}
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// dirtyVersion returns the version marked dirty, if any, blocking further
+// migrations until cleared with -force.
+func dirtyVersion(ctx context.Context, db *sql.DB, engine string) (int64, bool, error) {
+	var version int64
+	query := fmt.Sprintf("SELECT version FROM schema_migrations WHERE dirty = %s", trueLiteral(engine))
+	err := db.QueryRowContext(ctx, query).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to check dirty migration state: %w", err)
+	}
+	return version, true, nil
+}
+
+// runMigrate drives the migrate subcommand: it ensures the tracking table
+// and lock exist, then applies pending up migrations according to cfg.
+// Migrations are never reverted: .down.sql files are discovered but not run.
+func runMigrate(ctx context.Context, db *sql.DB, cfg config) error {
+	if cfg.force >= 0 {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("UPDATE schema_migrations SET dirty = %s WHERE version = %d", falseLiteral(cfg.engine), cfg.force)); err != nil {
+			return fmt.Errorf("failed to force-clear version %d: %w", cfg.force, err)
+		}
+		fmt.Printf("cleared dirty flag on version %d\n", cfg.force)
+		return nil
+	}
+
+	if ddl := createSchemaMigrationsTableDDL(cfg.engine); ddl != "" {
+		if _, err := db.ExecContext(ctx, ddl); err != nil {
+			return fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+	}
+
+	release, err := acquireMigrationLock(ctx, db, cfg.engine)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if version, dirty, err := dirtyVersion(ctx, db, cfg.engine); err != nil {
+		return err
+	} else if dirty {
+		return fmt.Errorf("database is dirty at version %d, fix it and run -force %d", version, version)
+	}
+
+	migrations, err := loadMigrations(cfg.migrationsDir)
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	pending := make([]migration, 0, len(migrations))
+	for _, m := range migrations {
+		if cfg.target > 0 && m.version > cfg.target {
+			break
+		}
+		if !applied[m.version] {
+			pending = append(pending, m)
+		}
+	}
+
+	if cfg.steps > 0 && cfg.steps < len(pending) {
+		pending = pending[:cfg.steps]
+	}
+
+	for _, m := range pending {
+		if m.upPath == "" {
+			return fmt.Errorf("migration %d has no .up.sql file", m.version)
+		}
+		if err := applyMigration(ctx, db, cfg.engine, m); err != nil {
+			return err
+		}
+		fmt.Printf("applied migration %d (%s)\n", m.version, m.description)
+	}
+
+	if len(pending) == 0 {
+		fmt.Println("no pending migrations")
+	}
+	return nil
+}
+
+// applyMigration runs a single migration's up script in one transaction,
+// marking the version dirty before running and clearing it on success. A
+// failure leaves the row dirty so the next run refuses to proceed.
+func applyMigration(ctx context.Context, db *sql.DB, engine string, m migration) error {
+	trueLit, falseLit := trueLiteral(engine), falseLiteral(engine)
+
+	if _, err := db.ExecContext(ctx,
+		fmt.Sprintf("INSERT INTO schema_migrations (version, dirty) VALUES (%d, %s)", m.version, trueLit)); err != nil {
+		return fmt.Errorf("failed to record migration %d as dirty: %w", m.version, err)
+	}
+
+	sqlBytes, err := os.ReadFile(m.upPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", m.upPath, err)
+	}
+	statements := splitSQLStatements(string(sqlBytes))
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction for migration %d: %w", m.version, err)
+	}
+	for idx, statement := range statements {
+		if err := executeStatement(ctx, tx, idx+1, statement, config{format: "table"}, os.Stdout); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d failed on statement %d: %w", m.version, idx+1, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.version, err)
+	}
+
+	if _, err := db.ExecContext(ctx,
+		fmt.Sprintf("UPDATE schema_migrations SET dirty = %s WHERE version = %d", falseLit, m.version)); err != nil {
+		return fmt.Errorf("migration %d applied but failed to clear dirty flag: %w", m.version, err)
+	}
+	return nil
+}
+
+func trueLiteral(engine string) string {
+	switch strings.ToLower(engine) {
+	case "oracle", "sqlserver":
+		return "1"
+	}
+	return "TRUE"
+}
+
+func falseLiteral(engine string) string {
+	switch strings.ToLower(engine) {
+	case "oracle", "sqlserver":
+		return "0"
+	}
+	return "FALSE"
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// openOutput resolves -output to a writer: an empty path means stdout,
+// otherwise the named file is created/truncated. The returned close func is
+// always safe to call, even for stdout.
+func openOutput(path string) (io.Writer, func(), error) {
+	if path == "" {
+		return os.Stdout, func() {}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open -output file: %w", err)
+	}
+	return f, func() { _ = f.Close() }, nil
+}
+
+// RowWriter renders a query result set incrementally so large exports don't
+// have to be buffered in memory the way the original [][]string table did.
+type RowWriter interface {
+	WriteHeader(columns []string, types []*sql.ColumnType) error
+	WriteRow(values []any) error
+	Close() error
+}
+
+// newRowWriter builds the RowWriter for -format, writing to w. nullString
+// controls how NULL values render in csv, tsv, markdown and expanded output;
+// table output always prints NULL, and json/ndjson use the JSON null literal.
+func newRowWriter(format string, w io.Writer, nullString string) (RowWriter, error) {
+	switch format {
+	case "", "table":
+		return &tableRowWriter{w: w}, nil
+	case "json":
+		return &jsonRowWriter{w: w}, nil
+	case "ndjson":
+		return &ndjsonRowWriter{w: bufio.NewWriter(w)}, nil
+	case "csv":
+		return newDelimitedRowWriter(w, ',', nullString), nil
+	case "tsv":
+		return newDelimitedRowWriter(w, '\t', nullString), nil
+	case "markdown":
+		return &markdownRowWriter{w: w, nullString: nullString}, nil
+	case "expanded":
+		return &expandedRowWriter{w: w, nullString: nullString}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", format)
+	}
+}
+
+// columnKind classifies a result column so writers can format numeric
+// values correctly even when the driver scans them into an `any`
+// destination as []byte (e.g. go-sql-driver/mysql's DECIMAL columns). The
+// writers only consult kindNumeric: any other []byte value is treated as
+// binary (base64 in JSON, hex in csv/tsv), and time values are formatted
+// only when scanned as time.Time.
+type columnKind int
+
+const (
+	kindOther columnKind = iota
+	kindNumeric
+	kindBinary
+	kindTime
+)
+
+// classifyColumns derives a columnKind per column from *sql.ColumnType,
+// preferring the engine-reported DatabaseTypeName and falling back to the
+// driver's ScanType when the type name isn't recognized.
+func classifyColumns(types []*sql.ColumnType) []columnKind {
+	kinds := make([]columnKind, len(types))
+	for i, t := range types {
+		kinds[i] = classifyColumn(t)
+	}
+	return kinds
+}
+
+func classifyColumn(t *sql.ColumnType) columnKind {
+	if t == nil {
+		return kindOther
+	}
+	name := strings.ToUpper(t.DatabaseTypeName())
+	switch {
+	case strings.Contains(name, "DECIMAL"), strings.Contains(name, "NUMERIC"), strings.Contains(name, "NUMBER"),
+		strings.Contains(name, "INT"), strings.Contains(name, "FLOAT"), strings.Contains(name, "DOUBLE"), strings.Contains(name, "REAL"):
+		return kindNumeric
+	case strings.Contains(name, "BLOB"), strings.Contains(name, "BINARY"), strings.Contains(name, "BYTEA"):
+		return kindBinary
+	case strings.Contains(name, "DATE"), strings.Contains(name, "TIME"):
+		return kindTime
+	}
+
+	if st := t.ScanType(); st != nil {
+		switch st.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return kindNumeric
+		}
+	}
+	return kindOther
+}
+
+// formatScalar renders a single scanned value as a display string, also
+// reporting whether it is a number. kind lets a numeric column scanned as
+// []byte (DECIMAL/NUMERIC on some drivers) still count as a number. The
+// JSON writers do not use it; they go through jsonValue.
+func formatScalar(value any, kind columnKind) (display string, isNumber bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case []byte:
+		if kind == kindNumeric {
+			return string(v), true
+		}
+		return string(v), false
+	case time.Time:
+		return v.Format(time.RFC3339), false
+	case int64, int32, int, float64, float32:
+		return fmt.Sprint(v), true
+	case bool:
+		return fmt.Sprint(v), false
+	default:
+		return fmt.Sprint(v), kind == kindNumeric
+	}
+}
+
+// tableRowWriter reproduces the original human-readable +---+ box, which
+// requires buffering rows to compute column widths.
+type tableRowWriter struct {
+	w       io.Writer
+	columns []string
+	kinds   []columnKind
+	rows    [][]string
+}
+
+func (t *tableRowWriter) WriteHeader(columns []string, types []*sql.ColumnType) error {
+	t.columns = columns
+	t.kinds = classifyColumns(types)
+	return nil
+}
+
+func (t *tableRowWriter) WriteRow(values []any) error {
+	row := make([]string, len(values))
+	for i, value := range values {
+		if value == nil {
+			row[i] = "NULL"
+			continue
+		}
+		display, _ := formatScalar(value, t.kinds[i])
+		row[i] = display
+	}
+	t.rows = append(t.rows, row)
+	return nil
+}
+
+func (t *tableRowWriter) Close() error {
+	widths := make([]int, len(t.columns))
+	for i, col := range t.columns {
+		widths[i] = len(col)
+	}
+	for _, row := range t.rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	writeTableSeparator(t.w, widths)
+	writeTableRow(t.w, t.columns, widths)
+	writeTableSeparator(t.w, widths)
+	for _, row := range t.rows {
+		writeTableRow(t.w, row, widths)
+	}
+	writeTableSeparator(t.w, widths)
+	return nil
+}
+
+func writeTableSeparator(w io.Writer, widths []int) {
+	var sb strings.Builder
+	sb.WriteString("+")
+	for _, width := range widths {
+		sb.WriteString(strings.Repeat("-", width+2))
+		sb.WriteString("+")
+	}
+	fmt.Fprintln(w, sb.String())
+}
+
+func writeTableRow(w io.Writer, row []string, widths []int) {
+	var sb strings.Builder
+	sb.WriteString("|")
+	for i, cell := range row {
+		padding := widths[i] - len(cell)
+		sb.WriteString(" ")
+		sb.WriteString(cell)
+		sb.WriteString(strings.Repeat(" ", padding+1))
+		sb.WriteString("|")
+	}
+	fmt.Fprintln(w, sb.String())
+}
+
+// jsonRowWriter buffers the whole result set into a single JSON array,
+// since a JSON array can't be closed until every row is known.
+type jsonRowWriter struct {
+	w       io.Writer
+	columns []string
+	kinds   []columnKind
+	rows    []map[string]json.RawMessage
+}
+
+func (j *jsonRowWriter) WriteHeader(columns []string, types []*sql.ColumnType) error {
+	j.columns = columns
+	j.kinds = classifyColumns(types)
+	return nil
+}
+
+func (j *jsonRowWriter) WriteRow(values []any) error {
+	row := make(map[string]json.RawMessage, len(values))
+	for i, value := range values {
+		raw, err := jsonValue(value, j.kinds[i])
+		if err != nil {
+			return err
+		}
+		row[j.columns[i]] = raw
+	}
+	j.rows = append(j.rows, row)
+	return nil
+}
+
+func (j *jsonRowWriter) Close() error {
+	enc := json.NewEncoder(j.w)
+	return enc.Encode(j.rows)
+}
+
+// ndjsonRowWriter streams one JSON object per line as rows arrive, so
+// exporting a large result set never buffers it in memory.
+type ndjsonRowWriter struct {
+	w       *bufio.Writer
+	columns []string
+	kinds   []columnKind
+}
+
+func (n *ndjsonRowWriter) WriteHeader(columns []string, types []*sql.ColumnType) error {
+	n.columns = columns
+	n.kinds = classifyColumns(types)
+	return nil
+}
+
+func (n *ndjsonRowWriter) WriteRow(values []any) error {
+	row := make(map[string]json.RawMessage, len(values))
+	for i, value := range values {
+		raw, err := jsonValue(value, n.kinds[i])
+		if err != nil {
+			return err
+		}
+		row[n.columns[i]] = raw
+	}
+	encoded, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if _, err := n.w.Write(encoded); err != nil {
+		return err
+	}
+	return n.w.WriteByte('\n')
+}
+
+func (n *ndjsonRowWriter) Close() error {
+	return n.w.Flush()
+}
+
+// jsonValue renders a single scanned value as a JSON value: numeric columns
+// stay unquoted numbers, []byte is base64 (unless kind says it's really a
+// numeric column scanned as bytes, e.g. DECIMAL on some drivers), NULL is
+// the JSON null literal.
+func jsonValue(value any, kind columnKind) (json.RawMessage, error) {
+	switch v := value.(type) {
+	case nil:
+		return json.RawMessage("null"), nil
+	case []byte:
+		if kind == kindNumeric {
+			return json.RawMessage(strings.TrimSpace(string(v))), nil
+		}
+		encoded, err := json.Marshal(base64.StdEncoding.EncodeToString(v))
+		return encoded, err
+	case time.Time:
+		encoded, err := json.Marshal(v.Format(time.RFC3339))
+		return encoded, err
+	case int64, int32, int, float64, float32:
+		return json.RawMessage(fmt.Sprint(v)), nil
+	default:
+		if kind == kindNumeric {
+			return json.RawMessage(fmt.Sprint(v)), nil
+		}
+		encoded, err := json.Marshal(fmt.Sprint(v))
+		return encoded, err
+	}
+}
+
+// delimitedRowWriter backs both -format csv and -format tsv.
+type delimitedRowWriter struct {
+	writer     *csv.Writer
+	nullString string
+	kinds      []columnKind
+}
+
+func newDelimitedRowWriter(w io.Writer, comma rune, nullString string) *delimitedRowWriter {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	return &delimitedRowWriter{writer: cw, nullString: nullString}
+}
+
+func (d *delimitedRowWriter) WriteHeader(columns []string, types []*sql.ColumnType) error {
+	d.kinds = classifyColumns(types)
+	return d.writer.Write(columns)
+}
+
+func (d *delimitedRowWriter) WriteRow(values []any) error {
+	row := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case nil:
+			row[i] = d.nullString
+		case []byte:
+			if d.kinds[i] == kindNumeric {
+				row[i] = string(v)
+			} else {
+				row[i] = hex.EncodeToString(v)
+			}
+		default:
+			display, _ := formatScalar(v, d.kinds[i])
+			row[i] = display
+		}
+	}
+	return d.writer.Write(row)
+}
+
+func (d *delimitedRowWriter) Close() error {
+	d.writer.Flush()
+	return d.writer.Error()
+}
+
+// markdownRowWriter emits a GitHub-flavored Markdown pipe table.
+type markdownRowWriter struct {
+	w          io.Writer
+	nullString string
+	columns    []string
+	kinds      []columnKind
+	wroteRule  bool
+}
+
+func (m *markdownRowWriter) WriteHeader(columns []string, types []*sql.ColumnType) error {
+	m.columns = columns
+	m.kinds = classifyColumns(types)
+	fmt.Fprintln(m.w, "| "+strings.Join(columns, " | ")+" |")
+	return nil
+}
+
+func (m *markdownRowWriter) WriteRow(values []any) error {
+	if !m.wroteRule {
+		rule := make([]string, len(m.columns))
+		for i := range rule {
+			rule[i] = "---"
+		}
+		fmt.Fprintln(m.w, "| "+strings.Join(rule, " | ")+" |")
+		m.wroteRule = true
+	}
+	cells := make([]string, len(values))
+	for i, value := range values {
+		if value == nil {
+			cells[i] = m.nullString
+			continue
+		}
+		display, _ := formatScalar(value, m.kinds[i])
+		cells[i] = strings.ReplaceAll(display, "|", "\\|")
+	}
+	fmt.Fprintln(m.w, "| "+strings.Join(cells, " | ")+" |")
+	return nil
+}
+
+func (m *markdownRowWriter) Close() error {
+	if !m.wroteRule && len(m.columns) > 0 {
+		rule := make([]string, len(m.columns))
+		for i := range rule {
+			rule[i] = "---"
+		}
+		fmt.Fprintln(m.w, "| "+strings.Join(rule, " | ")+" |")
+	}
+	return nil
+}
+
+// expandedRowWriter renders one "key: value" line per column per row,
+// psql's \x / "expanded display", which reads far better than a wide table
+// once columns no longer fit a terminal width.
+type expandedRowWriter struct {
+	w          io.Writer
+	nullString string
+	columns    []string
+	kinds      []columnKind
+	record     int
+}
+
+func (e *expandedRowWriter) WriteHeader(columns []string, types []*sql.ColumnType) error {
+	e.columns = columns
+	e.kinds = classifyColumns(types)
+	return nil
+}
+
+func (e *expandedRowWriter) WriteRow(values []any) error {
+	e.record++
+	width := 0
+	for _, col := range e.columns {
+		if len(col) > width {
+			width = len(col)
+		}
+	}
+	fmt.Fprintf(e.w, "-[ RECORD %d ]%s\n", e.record, strings.Repeat("-", 40))
+	for i, value := range values {
+		display := e.nullString
+		if value != nil {
+			display, _ = formatScalar(value, e.kinds[i])
+		}
+		fmt.Fprintf(e.w, "%-*s | %s\n", width, e.columns[i], display)
+	}
+	return nil
+}
+
+func (e *expandedRowWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/peterh/liner"
+)
+
+const historyFileName = ".thin_cross_db_history"
+
+// runRepl drops into an interactive shell when the tool is invoked without
+// -sql, similar to psql/sqlcmd: statements are read a line at a time and
+// only executed once a terminating ';' is seen outside quotes/comments/
+// $$...$$ blocks, reusing the scanStatements state machine incrementally.
+func runRepl(cfg config) error {
+	db, err := sql.Open(driverName(cfg.engine), buildDSN(cfg))
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+
+	historyPath := historyFilePath()
+	if f, err := os.Open(historyPath); err == nil {
+		_, _ = line.ReadHistory(f)
+		f.Close()
+	}
+
+	r := &repl{
+		cfg:  cfg,
+		db:   db,
+		line: line,
+		out:  os.Stdout,
+	}
+	defer r.closeOutFile()
+	defer r.db.Close()
+
+	err = r.loop()
+
+	if f, werr := os.Create(historyPath); werr == nil {
+		_, _ = line.WriteHistory(f)
+		f.Close()
+	}
+	return err
+}
+
+func historyFilePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return historyFileName
+	}
+	return filepath.Join(home, historyFileName)
+}
+
+// repl holds the state that persists across the interactive session: the
+// connection, accumulated partial statement, and meta-command toggles.
+type repl struct {
+	cfg     config
+	db      *sql.DB
+	line    *liner.State
+	out     io.Writer
+	outFile *os.File
+	timing  bool
+	expand  bool
+	buffer  string
+}
+
+func (r *repl) closeOutFile() {
+	if r.outFile != nil {
+		_ = r.outFile.Close()
+	}
+}
+
+func (r *repl) loop() error {
+	for {
+		prompt := "thin-cross-db> "
+		if strings.TrimSpace(r.buffer) != "" {
+			prompt = "           -> "
+		}
+
+		text, err := r.line.Prompt(prompt)
+		if err == liner.ErrPromptAborted {
+			r.buffer = ""
+			continue
+		}
+		if err != nil {
+			return nil // EOF (Ctrl-D) ends the session cleanly
+		}
+		r.line.AppendHistory(text)
+
+		if strings.TrimSpace(r.buffer) == "" && strings.HasPrefix(strings.TrimSpace(text), "\\") {
+			if quit, err := r.handleMeta(strings.TrimSpace(text)); quit {
+				return nil
+			} else if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			continue
+		}
+
+		r.buffer += text + "\n"
+		complete, remainder := scanStatements(r.buffer)
+		r.buffer = remainder
+
+		for _, statement := range complete {
+			r.runStatement(statement)
+		}
+	}
+}
+
+// runStatement executes one complete statement outside any transaction
+// (autocommit), so REPL usage behaves like a normal interactive client.
+// Ctrl-C cancels the in-flight query via ctx rather than killing the
+// process.
+func (r *repl) runStatement(statement string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-done:
+		}
+	}()
+	defer func() {
+		close(done)
+		signal.Stop(sigCh)
+		cancel()
+	}()
+
+	start := time.Now()
+	if err := executeStatement(ctx, r.db, 1, statement, r.cfg, r.out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if r.timing {
+		fmt.Fprintf(os.Stderr, "Time: %s\n", time.Since(start))
+	}
+}
+
+// handleMeta implements the psql/sqlcmd-style backslash commands. It
+// returns quit=true for \q.
+func (r *repl) handleMeta(line string) (quit bool, err error) {
+	fields := strings.Fields(line)
+	cmd := fields[0]
+	arg := ""
+	if len(fields) > 1 {
+		arg = fields[1]
+	}
+
+	switch cmd {
+	case "\\q":
+		return true, nil
+	case "\\d":
+		return false, r.describe(arg)
+	case "\\dt":
+		return false, r.listTables()
+	case "\\di":
+		return false, r.listIndexes()
+	case "\\timing":
+		r.timing = !r.timing
+		fmt.Printf("timing is %s\n", onOff(r.timing))
+		return false, nil
+	case "\\x":
+		r.expand = !r.expand
+		if r.expand {
+			r.cfg.format = "expanded"
+		} else {
+			r.cfg.format = "table"
+		}
+		fmt.Printf("expanded display is %s\n", onOff(r.expand))
+		return false, nil
+	case "\\o":
+		return false, r.redirectOutput(arg)
+	case "\\i":
+		return false, r.runScript(arg)
+	case "\\c":
+		return false, r.reconnect()
+	default:
+		return false, fmt.Errorf("unknown meta-command: %s", cmd)
+	}
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+func (r *repl) redirectOutput(path string) error {
+	r.closeOutFile()
+	r.outFile = nil
+	if path == "" {
+		r.out = os.Stdout
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	r.outFile = f
+	r.out = f
+	return nil
+}
+
+func (r *repl) runScript(path string) error {
+	if path == "" {
+		return fmt.Errorf("\\i requires a file path")
+	}
+	sqlBytes, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	for _, statement := range splitSQLStatements(string(sqlBytes)) {
+		r.runStatement(statement)
+	}
+	return nil
+}
+
+func (r *repl) reconnect() error {
+	_ = r.db.Close()
+	db, err := sql.Open(driverName(r.cfg.engine), buildDSN(r.cfg))
+	if err != nil {
+		return fmt.Errorf("failed to reconnect: %w", err)
+	}
+	r.db = db
+	fmt.Println("reconnected")
+	return nil
+}
+
+// listTables and listIndexes run engine-specific catalog queries (pg_catalog,
+// INFORMATION_SCHEMA, sys.indexes, ALL_TABLES/ALL_INDEXES or sqlite_master,
+// depending on engine); see catalogQuery.
+func (r *repl) listTables() error {
+	query, args, err := catalogQuery(r.cfg.engine, "tables", "")
+	if err != nil {
+		return err
+	}
+	return r.runCatalogQuery(query, args...)
+}
+
+func (r *repl) listIndexes() error {
+	query, args, err := catalogQuery(r.cfg.engine, "indexes", "")
+	if err != nil {
+		return err
+	}
+	return r.runCatalogQuery(query, args...)
+}
+
+func (r *repl) describe(name string) error {
+	kind := "tables"
+	if name != "" {
+		kind = "columns"
+	}
+	query, args, err := catalogQuery(r.cfg.engine, kind, name)
+	if err != nil {
+		return err
+	}
+	return r.runCatalogQuery(query, args...)
+}
+
+func (r *repl) runCatalogQuery(query string, args ...any) error {
+	ctx := context.Background()
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return writeRows(rows, r.cfg, r.out)
+}
+
+// catalogQuery returns the per-engine catalog lookup for \d, \dt and \di,
+// along with the bind args for its placeholders. The "columns" queries bind
+// name as a parameter rather than splicing it into the SQL text, since it
+// comes straight from user input at the REPL prompt. SQLite's PRAGMA
+// table_info doesn't accept bind parameters for the table name, so that
+// case quotes name as an identifier instead.
+func catalogQuery(engineName, kind, name string) (string, []any, error) {
+	switch strings.ToLower(engineName) {
+	case "postgres":
+		switch kind {
+		case "tables":
+			return "SELECT schemaname, tablename FROM pg_catalog.pg_tables WHERE schemaname NOT IN ('pg_catalog', 'information_schema') ORDER BY 1, 2", nil, nil
+		case "indexes":
+			return "SELECT schemaname, tablename, indexname FROM pg_catalog.pg_indexes WHERE schemaname NOT IN ('pg_catalog', 'information_schema') ORDER BY 1, 2, 3", nil, nil
+		case "columns":
+			return "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_name = $1 ORDER BY ordinal_position", []any{name}, nil
+		}
+	case "sqlserver":
+		switch kind {
+		case "tables":
+			return "SELECT TABLE_SCHEMA, TABLE_NAME FROM INFORMATION_SCHEMA.TABLES ORDER BY 1, 2", nil, nil
+		case "indexes":
+			return "SELECT t.name AS table_name, i.name AS index_name FROM sys.indexes i JOIN sys.tables t ON t.object_id = i.object_id WHERE i.name IS NOT NULL ORDER BY 1, 2", nil, nil
+		case "columns":
+			return "SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_NAME = @p1 ORDER BY ORDINAL_POSITION", []any{name}, nil
+		}
+	case "oracle":
+		switch kind {
+		case "tables":
+			return "SELECT OWNER, TABLE_NAME FROM ALL_TABLES ORDER BY 1, 2", nil, nil
+		case "indexes":
+			return "SELECT TABLE_NAME, INDEX_NAME FROM ALL_INDEXES ORDER BY 1, 2", nil, nil
+		case "columns":
+			return "SELECT COLUMN_NAME, DATA_TYPE, NULLABLE FROM ALL_TAB_COLUMNS WHERE TABLE_NAME = UPPER(:1) ORDER BY COLUMN_ID", []any{name}, nil
+		}
+	case "sqlite":
+		switch kind {
+		case "tables":
+			return "SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name", nil, nil
+		case "indexes":
+			return "SELECT tbl_name, name FROM sqlite_master WHERE type = 'index' ORDER BY 1, 2", nil, nil
+		case "columns":
+			return fmt.Sprintf("PRAGMA table_info(%s)", sqliteEngine{}.QuoteIdentifier(name)), nil, nil
+		}
+	case "mysql":
+		switch kind {
+		case "tables":
+			return "SELECT TABLE_SCHEMA, TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys') ORDER BY 1, 2", nil, nil
+		case "indexes":
+			return "SELECT TABLE_NAME, INDEX_NAME FROM INFORMATION_SCHEMA.STATISTICS ORDER BY 1, 2", nil, nil
+		case "columns":
+			return "SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_NAME = ? ORDER BY ORDINAL_POSITION", []any{name}, nil
+		}
+	}
+	return "", nil, fmt.Errorf("unsupported engine for catalog lookup: %s", engineName)
+}